@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errExpiredLease возвращается Refresh, если аренда уже истекла
+var errExpiredLease = errors.New("lease manager: lease expired")
+
+// LeaseManager выдаёт именованные аренды (lease) с TTL. Каждая аренда
+// должна периодически продлеваться через возвращённую функцию refresh —
+// если этого не происходит дольше ttl, аренда считается истёкшей и
+// вызывается onExpire.
+//
+// Используется в ProcessFiles, чтобы воркер, зависший на обработке
+// одного файла (например, из-за огромного размера или медленного
+// диска), не держал слот пула обработки вечно: как только его аренда
+// истекает, слот пула освобождается для следующего файла.
+type LeaseManager struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	leases map[string]*lease
+}
+
+type lease struct {
+	onExpire func()
+	expired  bool
+}
+
+// NewLeaseManager создаёт LeaseManager с временем жизни аренды ttl
+func NewLeaseManager(ttl time.Duration) *LeaseManager {
+	return &LeaseManager{
+		ttl:    ttl,
+		leases: make(map[string]*lease),
+	}
+}
+
+// Acquire выдаёт новую аренду на key
+//
+// onExpire вызывается не более одного раза, если аренда не была
+// продлена в течение ttl с момента Acquire (или последнего Refresh).
+// Возвращает:
+//
+//	refresh - продлевает аренду ещё на ttl; возвращает errExpiredLease,
+//	  если аренда уже истекла
+//	release - снимает аренду и останавливает её таймер; вызывающий
+//	  должен вызвать release ровно один раз по завершении работы
+func (lm *LeaseManager) Acquire(key string, onExpire func()) (refresh func(ctx context.Context) error, release func()) {
+	l := &lease{onExpire: onExpire}
+
+	lm.mu.Lock()
+	lm.leases[key] = l
+	lm.mu.Unlock()
+
+	timer := time.AfterFunc(lm.ttl, func() { lm.expire(key, l) })
+
+	refresh = func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		lm.mu.Lock()
+		defer lm.mu.Unlock()
+
+		if l.expired {
+			return errExpiredLease
+		}
+
+		timer.Reset(lm.ttl)
+		return nil
+	}
+
+	release = func() {
+		timer.Stop()
+
+		lm.mu.Lock()
+		defer lm.mu.Unlock()
+
+		if lm.leases[key] == l {
+			delete(lm.leases, key)
+		}
+	}
+
+	return refresh, release
+}
+
+func (lm *LeaseManager) expire(key string, l *lease) {
+	lm.mu.Lock()
+	current, ok := lm.leases[key]
+	if !ok || current != l || l.expired {
+		lm.mu.Unlock()
+		return
+	}
+	l.expired = true
+	delete(lm.leases, key)
+	lm.mu.Unlock()
+
+	if l.onExpire != nil {
+		l.onExpire()
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLeaseManager_ExpiresWithoutRefresh(t *testing.T) {
+	lm := NewLeaseManager(20 * time.Millisecond)
+
+	var expired int32
+	_, release := lm.Acquire("file", func() { atomic.StoreInt32(&expired, 1) })
+	defer release()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if atomic.LoadInt32(&expired) != 1 {
+		t.Fatal("expected lease to expire when not refreshed")
+	}
+}
+
+func TestLeaseManager_RefreshPreventsExpiry(t *testing.T) {
+	lm := NewLeaseManager(20 * time.Millisecond)
+
+	var expired int32
+	refresh, release := lm.Acquire("file", func() { atomic.StoreInt32(&expired, 1) })
+	defer release()
+
+	ctx := context.Background()
+	deadline := time.Now().Add(60 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if err := refresh(ctx); err != nil {
+			t.Fatalf("unexpected refresh error: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if atomic.LoadInt32(&expired) != 0 {
+		t.Fatal("expected lease to stay alive while being refreshed")
+	}
+}
+
+func TestLeaseManager_ReleaseStopsExpiry(t *testing.T) {
+	lm := NewLeaseManager(10 * time.Millisecond)
+
+	var expired int32
+	_, release := lm.Acquire("file", func() { atomic.StoreInt32(&expired, 1) })
+	release()
+
+	time.Sleep(30 * time.Millisecond)
+
+	if atomic.LoadInt32(&expired) != 0 {
+		t.Fatal("expected release to prevent onExpire from firing")
+	}
+}
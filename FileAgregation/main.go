@@ -3,11 +3,34 @@ package main
 import (
 	"bufio"
 	"context"
+	"fmt"
+	"log"
 	"os"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/StewardMcCormick/LMSEntryRepo/metrics"
 )
 
+// fileLeaseTTL - время, в течение которого воркер должен обработать
+// файл, прежде чем его слот пула будет освобождён для других файлов
+const fileLeaseTTL = 30 * time.Second
+
+// Option настраивает поведение ProcessFiles
+type Option func(*processConfig)
+
+type processConfig struct {
+	metrics *metrics.Collectors
+}
+
+// WithMetrics включает экспорт метрик Prometheus через коллекторы c
+func WithMetrics(c *metrics.Collectors) Option {
+	return func(cfg *processConfig) {
+		cfg.metrics = c
+	}
+}
+
 // FileStats содержит статистику одного файла
 type FileStats struct {
 	Path        string // Путь к файлу
@@ -26,7 +49,13 @@ type AggregatedStats struct {
 	FileStats        []FileStats // Статистика по каждому файлу
 }
 
-func handleFile(ctx context.Context, file *os.File, totalUniqMap map[string]struct{}, minSize int64, mu *sync.Mutex) (FileStats, bool) {
+// leaseRefreshLines - раз в сколько просканированных строк handleFile
+// продлевает свою аренду в LeaseManager, подтверждая, что воркер ещё
+// делает прогресс, а не завис
+const leaseRefreshLines = 1000
+
+func handleFile(ctx context.Context, file *os.File, totalUniqMap map[string]struct{}, minSize int64, mu *sync.Mutex, cfg *processConfig, refresh func(context.Context) error) (FileStats, bool) {
+	start := time.Now()
 	fileInfo, _ := os.Stat(file.Name())
 	uniqWord := make(map[string]struct{})
 
@@ -43,6 +72,15 @@ func handleFile(ctx context.Context, file *os.File, totalUniqMap map[string]stru
 		for scanner.Scan() {
 			text := strings.ToLower(scanner.Text())
 			result.Lines++
+
+			if result.Lines%leaseRefreshLines == 0 {
+				if err := refresh(ctx); err != nil {
+					// аренда уже истекла (или контекст отменён) — слот
+					// пула отдан другому файлу, продолжать бессмысленно
+					return result, true
+				}
+			}
+
 			for _, s := range strings.Fields(text) {
 				if _, ok := uniqWord[s]; !ok {
 					uniqWord[s] = struct{}{}
@@ -59,6 +97,13 @@ func handleFile(ctx context.Context, file *os.File, totalUniqMap map[string]stru
 		}
 	}
 
+	if cfg.metrics != nil {
+		cfg.metrics.FilesTotal.Inc()
+		cfg.metrics.FileLines.Add(float64(result.Lines))
+		cfg.metrics.FileWords.Add(float64(result.Words))
+		cfg.metrics.FileProcessTime.Observe(time.Since(start).Seconds())
+	}
+
 	return result, true
 }
 
@@ -70,6 +115,7 @@ func handleFile(ctx context.Context, file *os.File, totalUniqMap map[string]stru
 //	filePaths - список путей к файлам
 //	maxWorkers - максимальное количество одновременно обрабатываемых файлов
 //	minSize - минимальный размер файла в байтах (меньшие игнорируются)
+//	opts - опции (например, WithMetrics для экспорта метрик Prometheus)
 //
 // Возвращает:
 //
@@ -82,7 +128,20 @@ func handleFile(ctx context.Context, file *os.File, totalUniqMap map[string]stru
 // 3. Прочитать файл и подсчитать статистику
 // 4. Одновременно обрабатывать не более maxWorkers файлов
 // 5. При отмене контекста вернуть то, что успели обработать
-func ProcessFiles(ctx context.Context, filePaths []string, maxWorkers int, minSize int64) (*AggregatedStats, error) {
+//
+// Каждый файл обрабатывается под арендой LeaseManager с TTL
+// fileLeaseTTL. handleFile продлевает эту аренду каждые leaseRefreshLines
+// просканированных строк, так что большой, но читающийся файл не
+// теряет свой слот пула; если воркер действительно зависает (не
+// продлевает аренду дольше fileLeaseTTL), аренда истекает и слот пула
+// освобождается для следующего файла, не дожидаясь завершения
+// зависшего воркера.
+func ProcessFiles(ctx context.Context, filePaths []string, maxWorkers int, minSize int64, opts ...Option) (*AggregatedStats, error) {
+	cfg := &processConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	pool := make(chan struct{}, maxWorkers)
 	for range maxWorkers {
 		pool <- struct{}{}
@@ -92,6 +151,7 @@ func ProcessFiles(ctx context.Context, filePaths []string, maxWorkers int, minSi
 	mu := &sync.Mutex{}
 	result := &AggregatedStats{}
 	totalUniqWordsMap := make(map[string]struct{})
+	lm := NewLeaseManager(fileLeaseTTL)
 
 	for _, path := range filePaths {
 		_ = <-pool
@@ -102,10 +162,15 @@ func ProcessFiles(ctx context.Context, filePaths []string, maxWorkers int, minSi
 			continue
 		}
 
+		var releaseSlot sync.Once
+		releaseSlotFunc := func() { releaseSlot.Do(func() { pool <- struct{}{} }) }
+		refreshLease, releaseLease := lm.Acquire(path, releaseSlotFunc)
+
 		wg.Add(1)
 		go func() {
 			defer func() {
-				pool <- struct{}{}
+				releaseLease()
+				releaseSlotFunc()
 				wg.Done()
 			}()
 
@@ -113,15 +178,17 @@ func ProcessFiles(ctx context.Context, filePaths []string, maxWorkers int, minSi
 			case <-ctx.Done():
 				return
 			default:
-				fileStats, status := handleFile(ctx, file, totalUniqWordsMap, minSize, mu)
+				fileStats, status := handleFile(ctx, file, totalUniqWordsMap, minSize, mu, cfg, refreshLease)
 				if !status {
 					return
 				}
 
+				mu.Lock()
 				result.FileStats = append(result.FileStats, fileStats)
 				result.TotalFiles++
 				result.TotalLines += fileStats.Lines
 				result.TotalWords += fileStats.Words
+				mu.Unlock()
 			}
 		}()
 	}
@@ -131,25 +198,25 @@ func ProcessFiles(ctx context.Context, filePaths []string, maxWorkers int, minSi
 	return result, nil
 }
 
-//func main() {
-//	ctx := context.Background()
-//
-//	filePaths := []string{
-//		"LMSEntry/FileAgregation/tmp/file1.txt",
-//	}
-//
-//	stats, err := ProcessFiles(ctx, filePaths, 2, 0) // maxWorkers=2, minSize=100 bytes
-//	if err != nil {
-//		log.Fatal(err)
-//	}
-//
-//	fmt.Printf("Processed %d files\n", stats.TotalFiles)
-//	fmt.Printf("Total lines: %d\n", stats.TotalLines)
-//	fmt.Printf("Total words: %d\n", stats.TotalWords)
-//	fmt.Printf("Unique words: %d\n", stats.TotalUniqueWords)
-//
-//	for _, fs := range stats.FileStats {
-//		fmt.Printf("%s: %d lines, %d words, %d unique\n",
-//			fs.Path, fs.Lines, fs.Words, fs.UniqueWords)
-//	}
-//}
+func main() {
+	ctx := context.Background()
+
+	filePaths := []string{
+		"tmp/file1.txt",
+	}
+
+	stats, err := ProcessFiles(ctx, filePaths, 2, 0) // maxWorkers=2, minSize=100 bytes
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Processed %d files\n", stats.TotalFiles)
+	fmt.Printf("Total lines: %d\n", stats.TotalLines)
+	fmt.Printf("Total words: %d\n", stats.TotalWords)
+	fmt.Printf("Unique words: %d\n", stats.TotalUniqueWords)
+
+	for _, fs := range stats.FileStats {
+		fmt.Printf("%s: %d lines, %d words, %d unique\n",
+			fs.Path, fs.Lines, fs.Words, fs.UniqueWords)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Регрессионный тест: горутины-воркеры раньше писали в result.FileStats
+// и счётчики AggregatedStats без синхронизации — гонка по данным под
+// -race. Здесь же проверяется, что аренда LeaseManager не мешает
+// обычной (не зависшей) обработке файлов.
+func TestProcessFiles_AggregatesWithoutRace(t *testing.T) {
+	dir := t.TempDir()
+
+	var paths []string
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(path, []byte("hello world\nfoo bar\n"), 0o644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	stats, err := ProcessFiles(context.Background(), paths, 4, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.TotalFiles != len(paths) {
+		t.Fatalf("expected %d files processed, got %d", len(paths), stats.TotalFiles)
+	}
+	if len(stats.FileStats) != len(paths) {
+		t.Fatalf("expected %d FileStats entries, got %d", len(paths), len(stats.FileStats))
+	}
+	if stats.TotalLines != 2*len(paths) {
+		t.Fatalf("expected %d total lines, got %d", 2*len(paths), stats.TotalLines)
+	}
+}
+
+// Регрессионный тест: раньше handleFile не получало функцию refresh и
+// никогда не продлевало свою аренду в LeaseManager, из-за чего любой
+// файл, обрабатывающийся дольше fileLeaseTTL, терял слот пула, даже
+// продолжая делать прогресс. Здесь проверяется, что refresh
+// действительно вызывается пропорционально числу просканированных строк.
+func TestHandleFile_RefreshesLeaseOnProgress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+
+	var lines []string
+	for i := 0; i < 3*leaseRefreshLines; i++ {
+		lines = append(lines, "word")
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open test file: %v", err)
+	}
+	defer file.Close()
+
+	var refreshCalls int32
+	refresh := func(ctx context.Context) error {
+		atomic.AddInt32(&refreshCalls, 1)
+		return nil
+	}
+
+	_, ok := handleFile(context.Background(), file, make(map[string]struct{}), 0, &sync.Mutex{}, &processConfig{}, refresh)
+	if !ok {
+		t.Fatal("expected handleFile to report success")
+	}
+
+	if got := atomic.LoadInt32(&refreshCalls); got < 3 {
+		t.Fatalf("expected at least 3 refresh calls for %d lines, got %d", len(lines), got)
+	}
+}
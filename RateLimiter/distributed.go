@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// decisionCacheTTL — на сколько кешируется решение owner'а на стороне
+// не-владельца, чтобы не ходить за каждым токеном по сети
+const decisionCacheTTL = 50 * time.Millisecond
+
+// KeyStats содержит статистику по одному ключу лимитера
+type KeyStats struct {
+	Allowed int64 // Количество разрешённых запросов
+	Denied  int64 // Количество отклонённых запросов
+}
+
+// takeRequest — тело запроса POST /_rl/take
+type takeRequest struct {
+	Key string `json:"key"`
+	N   int    `json:"n"`
+}
+
+// takeResponse — ответ owner'а на запрос токенов
+type takeResponse struct {
+	Allowed   bool  `json:"allowed"`
+	WaitMs    int64 `json:"wait_ms"`
+	Remaining int   `json:"remaining"`
+}
+
+type cachedDecision struct {
+	remaining int
+	expiresAt time.Time
+}
+
+// DistributedRateLimiter — RateLimiter, у которого одно логическое ведро
+// токенов разделяется между несколькими процессами (peers)
+//
+// Ключ лимитера (например, id клиента) хэшируется на одного из peers —
+// владельца (owner) этого ключа. Все решения по ключу принимает владелец,
+// остальные процессы пересылают ему Allow/Wait/WaitN по HTTP.
+type DistributedRateLimiter struct {
+	Rate     int
+	Capacity int
+	Peers    []string
+	Self     string
+
+	client *http.Client
+
+	mu       sync.Mutex
+	owned    map[string]*RateLimiter    // локальные ведра для ключей, которыми владеет этот узел
+	cache    map[string]*cachedDecision // кеш решений owner'а для не-владельца
+	keyStats map[string]*KeyStats
+}
+
+// NewDistributedRateLimiter создаёт распределённый rate limiter
+//
+// Параметры:
+//
+//	rate - количество токенов, добавляемых в секунду (на ключ)
+//	capacity - максимальное количество токенов в ведре (на ключ)
+//	peers - адреса всех узлов, участвующих в координации (включая self)
+//	self - адрес текущего узла, как он указан в peers
+func NewDistributedRateLimiter(rate, capacity int, peers []string, self string) *DistributedRateLimiter {
+	return &DistributedRateLimiter{
+		Rate:     rate,
+		Capacity: capacity,
+		Peers:    peers,
+		Self:     self,
+		client:   &http.Client{Timeout: 500 * time.Millisecond},
+		owned:    make(map[string]*RateLimiter),
+		cache:    make(map[string]*cachedDecision),
+		keyStats: make(map[string]*KeyStats),
+	}
+}
+
+// owner возвращает адрес узла, ответственного за key, выбранный
+// консистентным хэшированием среди drl.Peers
+func (drl *DistributedRateLimiter) owner(key string) string {
+	if len(drl.Peers) == 0 {
+		return drl.Self
+	}
+
+	peers := make([]string, len(drl.Peers))
+	copy(peers, drl.Peers)
+	sort.Strings(peers)
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum32()
+
+	return peers[int(sum)%len(peers)]
+}
+
+// localLimiter возвращает (создавая при необходимости) локальное ведро
+// для ключа, за который отвечает текущий узел
+func (drl *DistributedRateLimiter) localLimiter(key string) *RateLimiter {
+	drl.mu.Lock()
+	defer drl.mu.Unlock()
+
+	rl, ok := drl.owned[key]
+	if !ok {
+		rl = NewRateLimiter(drl.Rate, drl.Capacity)
+		drl.owned[key] = rl
+	}
+
+	return rl
+}
+
+func (drl *DistributedRateLimiter) recordStat(key string, allowed bool) {
+	drl.mu.Lock()
+	defer drl.mu.Unlock()
+
+	st, ok := drl.keyStats[key]
+	if !ok {
+		st = &KeyStats{}
+		drl.keyStats[key] = st
+	}
+
+	if allowed {
+		st.Allowed++
+	} else {
+		st.Denied++
+	}
+}
+
+// GetKeyStats возвращает статистику по конкретному ключу
+func (drl *DistributedRateLimiter) GetKeyStats(key string) KeyStats {
+	drl.mu.Lock()
+	defer drl.mu.Unlock()
+
+	if st, ok := drl.keyStats[key]; ok {
+		return *st
+	}
+
+	return KeyStats{}
+}
+
+// Allow проверяет, можно ли выполнить запрос для key прямо сейчас
+//
+// Если текущий узел — владелец key, решение принимается локально.
+// Иначе запрос пересылается владельцу по HTTP; при недоступности
+// владельца лимитер откатывается на локальное ведро (graceful fallback)
+func (drl *DistributedRateLimiter) Allow(key string) bool {
+	if drl.owner(key) == drl.Self {
+		allowed := drl.localLimiter(key).Allow()
+		drl.recordStat(key, allowed)
+		return allowed
+	}
+
+	if allowed, ok := drl.takeFromCache(key); ok {
+		drl.recordStat(key, allowed)
+		return allowed
+	}
+
+	resp, err := drl.takeRemote(key, 1)
+	if err != nil {
+		// owner недоступен — работаем как локальный лимитер
+		allowed := drl.localLimiter(key).Allow()
+		drl.recordStat(key, allowed)
+		return allowed
+	}
+
+	drl.cacheDecision(key, resp.Remaining)
+	drl.recordStat(key, resp.Allowed)
+	return resp.Allowed
+}
+
+// Wait блокируется до тех пор, пока для key не появится токен
+func (drl *DistributedRateLimiter) Wait(ctx context.Context, key string) error {
+	return drl.WaitN(ctx, key, 1)
+}
+
+// WaitN блокируется до получения n токенов для key
+func (drl *DistributedRateLimiter) WaitN(ctx context.Context, key string, n int) error {
+	if drl.owner(key) == drl.Self {
+		return drl.localLimiter(key).WaitN(ctx, n)
+	}
+
+	resp, err := drl.takeRemote(key, n)
+	if err != nil {
+		return drl.localLimiter(key).WaitN(ctx, n)
+	}
+
+	if resp.Allowed {
+		drl.cacheDecision(key, resp.Remaining)
+		drl.recordStat(key, true)
+		return nil
+	}
+
+	wait := time.Duration(resp.WaitMs) * time.Millisecond
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return drl.WaitN(ctx, key, n)
+	}
+}
+
+// takeFromCache пытается списать токен из локально закешированного
+// остатка, полученного от owner'а, не обращаясь к нему по сети
+func (drl *DistributedRateLimiter) takeFromCache(key string) (allowed bool, ok bool) {
+	drl.mu.Lock()
+	defer drl.mu.Unlock()
+
+	c, found := drl.cache[key]
+	if !found || time.Now().After(c.expiresAt) {
+		return false, false
+	}
+
+	if c.remaining <= 0 {
+		return false, true
+	}
+
+	c.remaining--
+	return true, true
+}
+
+func (drl *DistributedRateLimiter) cacheDecision(key string, remaining int) {
+	drl.mu.Lock()
+	defer drl.mu.Unlock()
+
+	drl.cache[key] = &cachedDecision{
+		remaining: remaining,
+		expiresAt: time.Now().Add(decisionCacheTTL),
+	}
+}
+
+// takeRemote пересылает запрос токенов владельцу key по HTTP
+func (drl *DistributedRateLimiter) takeRemote(key string, n int) (*takeResponse, error) {
+	body, err := json.Marshal(takeRequest{Key: key, N: n})
+	if err != nil {
+		return nil, err
+	}
+
+	url := drl.owner(key) + "/_rl/take"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := drl.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("distributed ratelimiter: owner returned status %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var out takeResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// Handler возвращает http.Handler, обслуживающий POST /_rl/take для
+// запросов токенов, присланных от не-владельцев этого ключа
+func (drl *DistributedRateLimiter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_rl/take", drl.handleTake)
+	return mux
+}
+
+func (drl *DistributedRateLimiter) handleTake(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req takeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	rl := drl.localLimiter(req.Key)
+	allowed := rl.AllowN(req.N)
+
+	remaining := rl.GetAvailableTokens()
+	waitMs := int64(0)
+	if !allowed && drl.Rate > 0 {
+		waitMs = (time.Second / time.Duration(drl.Rate)).Milliseconds()
+	}
+
+	drl.recordStat(req.Key, allowed)
+
+	resp := takeResponse{Allowed: allowed, WaitMs: waitMs, Remaining: remaining}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDistributedRateLimiter_OwnerIsDeterministic(t *testing.T) {
+	peers := []string{"node-a", "node-b", "node-c"}
+	drl := NewDistributedRateLimiter(5, 5, peers, "node-a")
+
+	first := drl.owner("client-42")
+	for i := 0; i < 10; i++ {
+		if got := drl.owner("client-42"); got != first {
+			t.Fatalf("owner(%q) is not deterministic: got %q, want %q", "client-42", got, first)
+		}
+	}
+}
+
+func TestDistributedRateLimiter_OwnerHandlesLocally(t *testing.T) {
+	drl := NewDistributedRateLimiter(5, 5, []string{"node-a"}, "node-a")
+
+	for i := 0; i < 5; i++ {
+		if !drl.Allow("client-1") {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+
+	if drl.Allow("client-1") {
+		t.Fatal("request should be rejected when bucket is empty")
+	}
+
+	stats := drl.GetKeyStats("client-1")
+	if stats.Allowed != 5 || stats.Denied != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestDistributedRateLimiter_ForwardsToOwner(t *testing.T) {
+	owner := NewDistributedRateLimiter(2, 2, []string{"owner"}, "owner")
+	srv := httptest.NewServer(owner.Handler())
+	defer srv.Close()
+
+	follower := NewDistributedRateLimiter(2, 2, []string{"owner", "follower"}, "follower")
+	// переопределяем owner() через подмену Peers/Self так, чтобы он
+	// всегда пересылал запросы на тестовый HTTP-сервер owner'а
+	follower.Peers = []string{srv.URL}
+	follower.Self = "follower"
+
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		if follower.Allow("client-9") {
+			allowed++
+		}
+	}
+
+	if allowed != 2 {
+		t.Fatalf("expected exactly 2 allowed requests forwarded to owner, got %d", allowed)
+	}
+}
+
+func TestDistributedRateLimiter_FallsBackWhenOwnerUnreachable(t *testing.T) {
+	follower := NewDistributedRateLimiter(3, 3, []string{"http://127.0.0.1:1"}, "follower")
+
+	if !follower.Allow("client-7") {
+		t.Fatal("expected local fallback to allow request when owner is unreachable")
+	}
+}
+
+func TestDistributedRateLimiter_WaitNRespectsContext(t *testing.T) {
+	drl := NewDistributedRateLimiter(1, 0, []string{"node-a"}, "node-a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := drl.WaitN(ctx, "client-5", 1); err == nil {
+		t.Fatal("expected error when context is already cancelled and bucket is empty")
+	}
+}
+
+// Регрессионный тест: handleTake раньше забирал токены по одному через
+// Allow() в цикле, и при отказе на i-м токене уже списанные i-1 токенов
+// не возвращались в ведро. Денай на N-токенном запросе не должен менять
+// количество доступных токенов.
+func TestDistributedRateLimiter_DeniedTakeDoesNotDrainBucket(t *testing.T) {
+	owner := NewDistributedRateLimiter(5, 5, []string{"owner"}, "owner")
+	srv := httptest.NewServer(owner.Handler())
+	defer srv.Close()
+
+	follower := NewDistributedRateLimiter(5, 5, []string{srv.URL}, "follower")
+
+	ctx := context.Background()
+	if err := follower.WaitN(ctx, "client-3", 3); err != nil {
+		t.Fatalf("expected first 3-token take to succeed, got %v", err)
+	}
+
+	remainingBefore := owner.localLimiter("client-3").GetAvailableTokens()
+	if remainingBefore != 2 {
+		t.Fatalf("expected 2 tokens remaining after first take, got %d", remainingBefore)
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if err := follower.WaitN(shortCtx, "client-3", 3); err == nil {
+		t.Fatal("expected second 3-token take to time out with only 2 tokens left")
+	}
+
+	remainingAfter := owner.localLimiter("client-3").GetAvailableTokens()
+	if remainingAfter != remainingBefore {
+		t.Fatalf("denied take must not drain the bucket: had %d tokens, now have %d", remainingBefore, remainingAfter)
+	}
+}
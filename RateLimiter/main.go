@@ -6,6 +6,8 @@ import (
 	"log"
 	"sync"
 	"time"
+
+	"github.com/StewardMcCormick/LMSEntryRepo/metrics"
 )
 
 // RateLimiter ограничивает скорость запросов
@@ -15,6 +17,18 @@ type RateLimiter struct {
 	CurrentTokensNum int
 	LastUpdate       time.Time
 	mu               *sync.Mutex
+
+	metrics *metrics.Collectors
+}
+
+// Option настраивает RateLimiter при создании
+type Option func(*RateLimiter)
+
+// WithMetrics включает экспорт метрик Prometheus через коллекторы c
+func WithMetrics(c *metrics.Collectors) Option {
+	return func(rl *RateLimiter) {
+		rl.metrics = c
+	}
 }
 
 // NewRateLimiter создаёт новый Rate limiter
@@ -28,7 +42,7 @@ type RateLimiter struct {
 //   - Каждую секунду добавляется 10 токенов
 //   - Максимум может накопиться 20 токенов
 //   - Можно сделать "burst" из 20 запросов, потом 10 запросов/сек
-func NewRateLimiter(rate int, capacity int) *RateLimiter {
+func NewRateLimiter(rate int, capacity int, opts ...Option) *RateLimiter {
 	limiter := &RateLimiter{
 		Rate:             rate,
 		Capacity:         capacity,
@@ -37,6 +51,10 @@ func NewRateLimiter(rate int, capacity int) *RateLimiter {
 		mu:               &sync.Mutex{},
 	}
 
+	for _, opt := range opts {
+		opt(limiter)
+	}
+
 	return limiter
 }
 
@@ -61,17 +79,53 @@ func (rl *RateLimiter) Allow() bool {
 		rl.CurrentTokensNum--
 		rl.mu.Unlock()
 
+		rl.reportTokens()
 		return true
 	}
 
+	if rl.metrics != nil {
+		rl.metrics.RateLimiterRejections.Inc()
+	}
+
 	return false
 }
 
+// AllowN проверяет, можно ли забрать n токенов прямо сейчас
+// В отличие от вызова Allow() в цикле, проверка и списание n токенов
+// происходят под одной блокировкой — запрос либо списывает все n
+// токенов, либо не трогает ведро вовсе (частичное списание с последующим
+// отказом невозможно)
+func (rl *RateLimiter) AllowN(n int) bool {
+	rl.UpdateTokensNum()
+
+	rl.mu.Lock()
+	if rl.CurrentTokensNum < n {
+		rl.mu.Unlock()
+
+		if rl.metrics != nil {
+			rl.metrics.RateLimiterRejections.Inc()
+		}
+		return false
+	}
+	rl.CurrentTokensNum -= n
+	rl.mu.Unlock()
+
+	rl.reportTokens()
+	return true
+}
+
+func (rl *RateLimiter) reportTokens() {
+	if rl.metrics != nil {
+		rl.metrics.RateLimiterTokensAvailable.Set(float64(rl.CurrentTokensNum))
+	}
+}
+
 // Wait блокируется до тех пор, пока не появится токен
 // Возвращает nil при успехе
 // Возвращает error при отмене контекста
 func (rl *RateLimiter) Wait(ctx context.Context) error {
-	timeToWait := time.Duration(1.0/rl.Rate) * time.Second
+	start := time.Now()
+	timeToWait := time.Duration(float64(time.Second) / float64(rl.Rate))
 	ticker := time.NewTicker(timeToWait)
 
 	select {
@@ -86,13 +140,20 @@ func (rl *RateLimiter) Wait(ctx context.Context) error {
 	rl.mu.Lock()
 	rl.CurrentTokensNum--
 	rl.mu.Unlock()
+
+	rl.reportTokens()
+	if rl.metrics != nil {
+		rl.metrics.RateLimiterWaitDuration.Observe(time.Since(start).Seconds())
+	}
+
 	return nil
 }
 
 // WaitN блокируется до получения n токенов
 // Полезно для операций, требующих несколько токенов
 func (rl *RateLimiter) WaitN(ctx context.Context, n int) error {
-	timeToWait := time.Duration(n/rl.Rate) * time.Second
+	start := time.Now()
+	timeToWait := time.Duration(float64(n) / float64(rl.Rate) * float64(time.Second))
 	ticker := time.NewTicker(timeToWait)
 
 	select {
@@ -107,6 +168,12 @@ func (rl *RateLimiter) WaitN(ctx context.Context, n int) error {
 	rl.mu.Lock()
 	rl.CurrentTokensNum -= n
 	rl.mu.Unlock()
+
+	rl.reportTokens()
+	if rl.metrics != nil {
+		rl.metrics.RateLimiterWaitDuration.Observe(time.Since(start).Seconds())
+	}
+
 	return nil
 }
 
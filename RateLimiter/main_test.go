@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -41,3 +42,31 @@ func TestRateLimiter_Refill(t *testing.T) {
 		t.Fatalf("expected 2 tokens after refill, got %d", count)
 	}
 }
+
+// Регрессионный тест: при Rate > 1 time.Duration(1.0/rl.Rate) усекается
+// целочисленным делением до 0, из-за чего time.NewTicker паникует.
+// Wait должен завершаться без паники и без блокировки дольше разумного
+// времени.
+func TestRateLimiter_Wait_DoesNotPanicWhenRateAboveOne(t *testing.T) {
+	limiter := NewRateLimiter(10, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Регрессионный тест: WaitN(ctx, n) с n < rl.Rate усекает n/rl.Rate до 0
+// той же целочисленной ошибкой.
+func TestRateLimiter_WaitN_DoesNotPanicWhenNBelowRate(t *testing.T) {
+	limiter := NewRateLimiter(10, 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := limiter.WaitN(ctx, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
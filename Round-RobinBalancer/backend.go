@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Backend представляет один backend-сервер и его состояние,
+// используемое стратегиями балансировки и проверками здоровья
+type Backend struct {
+	URL    string
+	Weight int
+
+	mu      sync.Mutex
+	healthy bool
+	breaker *circuitBreaker
+
+	activeConns int64 // текущее количество активных соединений, для LeastConnections
+
+	ewmaMu      sync.Mutex
+	ewmaLatency float64 // экспоненциально сглаженная задержка в миллисекундах, для P2C-EWMA
+
+	currentWeight int64 // вспомогательное поле для smooth weighted round-robin
+}
+
+func newBackend(url string, weight int) *Backend {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	return &Backend{
+		URL:     url,
+		Weight:  weight,
+		healthy: true,
+		breaker: newCircuitBreaker(),
+	}
+}
+
+// isAvailable сообщает, можно ли сейчас направлять трафик на backend —
+// он должен быть помечен как здоровый и его circuit breaker не должен
+// быть открыт
+func (b *Backend) isAvailable() bool {
+	b.mu.Lock()
+	healthy := b.healthy
+	b.mu.Unlock()
+
+	return healthy && b.breaker.allow()
+}
+
+func (b *Backend) setHealthy(healthy bool) {
+	b.mu.Lock()
+	b.healthy = healthy
+	b.mu.Unlock()
+}
+
+func (b *Backend) isHealthy() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.healthy
+}
+
+func (b *Backend) incConns() int64 {
+	return atomic.AddInt64(&b.activeConns, 1)
+}
+
+func (b *Backend) decConns() {
+	atomic.AddInt64(&b.activeConns, -1)
+}
+
+func (b *Backend) conns() int64 {
+	return atomic.LoadInt64(&b.activeConns)
+}
+
+// recordLatency обновляет EWMA задержки backend'а
+// alpha подобран так, чтобы недавние замеры весили больше, но разовый
+// выброс не уводил оценку слишком резко
+func (b *Backend) recordLatency(latencyMs float64) {
+	const alpha = 0.3
+
+	b.ewmaMu.Lock()
+	defer b.ewmaMu.Unlock()
+
+	if b.ewmaLatency == 0 {
+		b.ewmaLatency = latencyMs
+		return
+	}
+
+	b.ewmaLatency = alpha*latencyMs + (1-alpha)*b.ewmaLatency
+}
+
+func (b *Backend) latencyEstimate() float64 {
+	b.ewmaMu.Lock()
+	defer b.ewmaMu.Unlock()
+	return b.ewmaLatency
+}
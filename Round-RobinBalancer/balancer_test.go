@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestServer(status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+}
+
+func TestLoadBalancer_SkipsUnhealthyBackend(t *testing.T) {
+	bad := newTestServer(http.StatusInternalServerError)
+	defer bad.Close()
+	good := newTestServer(http.StatusOK)
+	defer good.Close()
+
+	lb := NewLoadBalancer([]string{bad.URL, good.URL})
+	lb.BackendPool()[0].setHealthy(false)
+
+	if _, err := lb.Get(context.Background(), "/ping"); err != nil {
+		t.Fatalf("expected request to succeed via healthy backend, got %v", err)
+	}
+}
+
+func TestLoadBalancer_CircuitBreakerOpensOnFailures(t *testing.T) {
+	bad := newTestServer(http.StatusInternalServerError)
+	defer bad.Close()
+
+	lb := NewLoadBalancer([]string{bad.URL})
+	backend := lb.BackendPool()[0]
+
+	for i := 0; i < breakerWindowSize; i++ {
+		_, _ = lb.Get(context.Background(), "/ping")
+	}
+
+	if backend.isAvailable() {
+		t.Fatal("expected circuit breaker to open after a window of failures")
+	}
+}
+
+func TestLoadBalancer_NoBackendsReturnsError(t *testing.T) {
+	lb := NewLoadBalancer(nil)
+
+	if _, err := lb.Get(context.Background(), "/ping"); err == nil {
+		t.Fatal("expected error when no backends are configured")
+	}
+}
+
+func TestLeastConnectionsStrategy_PrefersIdleBackend(t *testing.T) {
+	a := newBackend("http://a", 1)
+	b := newBackend("http://b", 1)
+	a.incConns()
+
+	strategy := NewLeastConnectionsStrategy([]*Backend{a, b})
+	backend, release := strategy.Pick(nil)
+
+	if backend != "http://b" {
+		t.Fatalf("expected least-busy backend http://b, got %s", backend)
+	}
+
+	release(http.StatusOK, time.Millisecond)
+}
+
+func TestWeightedStrategy_DistributesProportionally(t *testing.T) {
+	a := newBackend("http://a", 3)
+	b := newBackend("http://b", 1)
+	strategy := NewWeightedStrategy([]*Backend{a, b})
+
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		backend, release := strategy.Pick(nil)
+		counts[backend]++
+		release(http.StatusOK, time.Millisecond)
+	}
+
+	if counts["http://a"] != 6 || counts["http://b"] != 2 {
+		t.Fatalf("expected 6/2 split for weights 3/1, got %+v", counts)
+	}
+}
+
+// Регрессионный тест: BalancerStats.AddRequest раньше создавал новый
+// sync.Mutex на каждый вызов, то есть никого не защищал. Под -race
+// конкурентная запись TotalRequests/RequestsPerBackend падала бы.
+func TestBalancerStats_AddRequestIsRaceFree(t *testing.T) {
+	stats := newBalancerStats()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats.AddRequest("http://backend")
+		}()
+	}
+	wg.Wait()
+
+	if stats.TotalRequests != 50 {
+		t.Fatalf("expected TotalRequests=50, got %d", stats.TotalRequests)
+	}
+	if stats.RequestsPerBackend["http://backend"] != 50 {
+		t.Fatalf("expected 50 requests recorded for backend, got %d", stats.RequestsPerBackend["http://backend"])
+	}
+}
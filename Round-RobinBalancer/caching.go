@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StewardMcCormick/LMSEntryRepo/cache"
+)
+
+// defaultCacheCapacity - вместимость RAM-кеша CachingLoadBalancer (в
+// записях); сам по себе не знает об HTTP-семантике, поэтому свежесть и
+// stale-while-revalidate CachingLoadBalancer считает самостоятельно
+const defaultCacheCapacity = 1000
+
+// cacheControl — разобранные директивы заголовка Cache-Control
+type cacheControl struct {
+	noStore              bool
+	private              bool
+	mustRevalidate       bool
+	maxAge               time.Duration
+	hasMaxAge            bool
+	staleWhileRevalidate time.Duration
+}
+
+func parseCacheControl(header http.Header) cacheControl {
+	var cc cacheControl
+
+	for _, raw := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(raw, ",") {
+			directive = strings.TrimSpace(directive)
+			name, value, _ := strings.Cut(directive, "=")
+			name = strings.ToLower(strings.TrimSpace(name))
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+
+			switch name {
+			case "no-store":
+				cc.noStore = true
+			case "private":
+				cc.private = true
+			case "must-revalidate":
+				cc.mustRevalidate = true
+			case "max-age":
+				if n, err := strconv.Atoi(value); err == nil {
+					cc.maxAge = time.Duration(n) * time.Second
+					cc.hasMaxAge = true
+				}
+			case "stale-while-revalidate":
+				if n, err := strconv.Atoi(value); err == nil {
+					cc.staleWhileRevalidate = time.Duration(n) * time.Second
+				}
+			}
+		}
+	}
+
+	return cc
+}
+
+// varyHeaderNames разбирает заголовок Vary ответа на список имён
+// заголовков запроса, от значений которых зависит кешированный ответ
+func varyHeaderNames(header http.Header) []string {
+	raw := header.Get("Vary")
+	if raw == "" || raw == "*" {
+		return nil
+	}
+
+	var names []string
+	for _, part := range strings.Split(raw, ",") {
+		name := strings.TrimSpace(part)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CachingLoadBalancer оборачивает LoadBalancer in-memory кешем HTTP-ответов,
+// соблюдающим семантику Cache-Control/Expires/ETag/Last-Modified/Vary
+//
+// Хранение тел/статусов/заголовков полностью делегировано cache.CacheManager
+// (через SetHTTP/GetHTTP/GetHTTPEntry) — CachingLoadBalancer добавляет
+// поверх только то, что специфично для HTTP-кеширования и не является
+// общей задачей CacheManager: окно stale-while-revalidate и индекс имён
+// заголовков Vary по path.
+type CachingLoadBalancer struct {
+	*LoadBalancer
+
+	cache *cache.CacheManager
+
+	mu            sync.Mutex
+	servableUntil map[string]time.Time // key -> до какого момента запись ещё можно отдавать как stale
+	inFlight      map[string]bool
+}
+
+// NewCachingLoadBalancer создаёт кеширующий балансировщик поверх lb
+func NewCachingLoadBalancer(lb *LoadBalancer) *CachingLoadBalancer {
+	return &CachingLoadBalancer{
+		LoadBalancer:  lb,
+		cache:         cache.NewCacheManager(defaultCacheCapacity, time.Minute, time.Minute),
+		servableUntil: make(map[string]time.Time),
+		inFlight:      make(map[string]bool),
+	}
+}
+
+func cacheKey(path string, header http.Header, vary []string) string {
+	key := "GET " + path
+	for _, name := range vary {
+		key += "\x00" + name + "=" + header.Get(name)
+	}
+	return key
+}
+
+// varyIndexKey — ключ, под которым в cache хранится список имён
+// заголовков Vary последнего ответа для path (отдельно от самого
+// закешированного ответа, который адресуется через cacheKey)
+func varyIndexKey(path string) string {
+	return "vary\x00" + path
+}
+
+// varyNamesFor возвращает имена заголовков Vary, под которые нужно
+// ключевать запрос к path, по данным предыдущего закешированного ответа
+func (clb *CachingLoadBalancer) varyNamesFor(path string) []string {
+	v, ok := clb.cache.Get(varyIndexKey(path))
+	if !ok {
+		return nil
+	}
+
+	names, ok := v.([]string)
+	if !ok {
+		return nil
+	}
+	return names
+}
+
+func (clb *CachingLoadBalancer) setServableUntil(key string, until time.Time) {
+	clb.mu.Lock()
+	clb.servableUntil[key] = until
+	clb.mu.Unlock()
+}
+
+func (clb *CachingLoadBalancer) servableStale(key string, now time.Time) bool {
+	clb.mu.Lock()
+	until, ok := clb.servableUntil[key]
+	clb.mu.Unlock()
+
+	return ok && now.Before(until)
+}
+
+// Get читает path, используя кеш, если есть валидная или ещё пригодная
+// для stale-while-revalidate запись. header используется как для условной
+// валидации (If-None-Match/If-Modified-Since), так и для вычисления ключа
+// кеша по заголовкам, перечисленным в Vary предыдущего ответа для path.
+//
+// Алгоритм:
+//  1. Если есть свежая запись — вернуть её без обращения к backend'у.
+//  2. Если запись устарела, но укладывается в stale-while-revalidate —
+//     вернуть её и в фоне обновить кеш.
+//  3. Иначе сходить к backend'у, приложив If-None-Match/If-Modified-Since
+//     если есть что валидировать, и 304 превратить обратно в кешированное
+//     тело.
+func (clb *CachingLoadBalancer) Get(ctx context.Context, path string, header http.Header) ([]byte, error) {
+	key := cacheKey(path, header, clb.varyNamesFor(path))
+
+	status, cachedHeader, body, stale, ok := clb.cache.GetHTTPEntry(key)
+
+	now := time.Now()
+	if ok {
+		if !stale {
+			return body, nil
+		}
+
+		if clb.servableStale(key, now) {
+			clb.revalidateAsync(path, header, key)
+			return body, nil
+		}
+	}
+
+	fetchedBody, _, err := clb.fetch(ctx, path, header, key, status, cachedHeader, body, ok)
+	if err != nil && ok {
+		// backend недоступен — отдаём то, что успели закешировать ранее
+		return body, nil
+	}
+
+	return fetchedBody, err
+}
+
+// fetch идёт к backend'у (с условной валидацией, если entry было найдено)
+// и обновляет кеш результатом
+func (clb *CachingLoadBalancer) fetch(ctx context.Context, path string, header http.Header, key string, cachedStatus int, cachedHeader http.Header, cachedBody []byte, entryOK bool) ([]byte, int, error) {
+	condHeader := http.Header{}
+	for k, v := range header {
+		condHeader[k] = v
+	}
+	if entryOK {
+		if etag := cachedHeader.Get("ETag"); etag != "" {
+			condHeader.Set("If-None-Match", etag)
+		}
+		if lastModified := cachedHeader.Get("Last-Modified"); lastModified != "" {
+			condHeader.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, _, err := clb.RoundTrip(ctx, http.MethodGet, path, nil, condHeader)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entryOK {
+		clb.store(path, key, cachedStatus, cachedHeader, cachedBody)
+		return cachedBody, cachedStatus, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	clb.store(path, key, resp.StatusCode, resp.Header, body)
+	return body, resp.StatusCode, nil
+}
+
+func (clb *CachingLoadBalancer) revalidateAsync(path string, header http.Header, key string) {
+	clb.mu.Lock()
+	if clb.inFlight[key] {
+		clb.mu.Unlock()
+		return
+	}
+	clb.inFlight[key] = true
+	clb.mu.Unlock()
+
+	go func() {
+		defer func() {
+			clb.mu.Lock()
+			delete(clb.inFlight, key)
+			clb.mu.Unlock()
+		}()
+
+		status, cachedHeader, body, _, ok := clb.cache.GetHTTPEntry(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, _, _ = clb.fetch(ctx, path, header, key, status, cachedHeader, body, ok)
+	}()
+}
+
+// store сохраняет ответ в cache через SetHTTP и обновляет вспомогательные
+// индексы (Vary, окно stale-while-revalidate). Ответы с Cache-Control:
+// no-store или private не кешируются — private означает, что ответ
+// персонализирован и не должен оседать в общем (shared) кеше балансировщика.
+func (clb *CachingLoadBalancer) store(path, key string, status int, header http.Header, body []byte) {
+	cc := parseCacheControl(header)
+	if cc.noStore || cc.private {
+		clb.cache.Delete(key)
+		return
+	}
+
+	ttl := defaultCacheTTL(header, cc)
+
+	clb.cache.SetHTTP(key, status, header, body, ttl)
+	clb.setServableUntil(key, time.Now().Add(ttl+cc.staleWhileRevalidate))
+
+	if varyNames := varyHeaderNames(header); len(varyNames) > 0 {
+		clb.cache.Set(varyIndexKey(path), varyNames)
+	}
+}
+
+// defaultCacheTTL вычисляет, на сколько запись считается свежей, исходя
+// из Cache-Control max-age, а если его нет — из заголовка Expires
+func defaultCacheTTL(header http.Header, cc cacheControl) time.Duration {
+	if cc.mustRevalidate {
+		return 0
+	}
+
+	if cc.hasMaxAge {
+		return cc.maxAge
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		if t, err := http.ParseTime(expires); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+		return 0
+	}
+
+	return 0
+}
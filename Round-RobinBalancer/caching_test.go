@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachingLoadBalancer_ServesFreshFromCache(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	clb := NewCachingLoadBalancer(NewLoadBalancer([]string{srv.URL}))
+
+	for i := 0; i < 3; i++ {
+		body, err := clb.Get(context.Background(), "/data", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(body) != "hello" {
+			t.Fatalf("unexpected body: %s", body)
+		}
+	}
+
+	if atomic.LoadInt64(&hits) != 1 {
+		t.Fatalf("expected backend to be hit once, got %d", hits)
+	}
+}
+
+func TestCachingLoadBalancer_RevalidatesWith304(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&hits, 1)
+		if n == 1 {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "max-age=0, must-revalidate")
+			w.Write([]byte("payload"))
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Write([]byte("changed"))
+	}))
+	defer srv.Close()
+
+	clb := NewCachingLoadBalancer(NewLoadBalancer([]string{srv.URL}))
+
+	first, err := clb.Get(context.Background(), "/data", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(first) != "payload" {
+		t.Fatalf("unexpected body: %s", first)
+	}
+
+	second, err := clb.Get(context.Background(), "/data", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(second) != "payload" {
+		t.Fatalf("expected 304 to promote cached body, got: %s", second)
+	}
+
+	if atomic.LoadInt64(&hits) != 2 {
+		t.Fatalf("expected 2 backend requests (1 miss + 1 revalidation), got %d", hits)
+	}
+}
+
+func TestCachingLoadBalancer_StaleWhileRevalidate(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=5")
+		w.Write([]byte("v1"))
+	}))
+	defer srv.Close()
+
+	clb := NewCachingLoadBalancer(NewLoadBalancer([]string{srv.URL}))
+
+	body, err := clb.Get(context.Background(), "/data", nil)
+	if err != nil || string(body) != "v1" {
+		t.Fatalf("unexpected first response: %s, %v", body, err)
+	}
+
+	// запись уже устарела (max-age=0), но попадает в окно
+	// stale-while-revalidate — должна отдаться немедленно
+	body, err = clb.Get(context.Background(), "/data", nil)
+	if err != nil || string(body) != "v1" {
+		t.Fatalf("expected stale body to be served, got %s, %v", body, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if atomic.LoadInt64(&hits) < 2 {
+		t.Fatal("expected background revalidation to have hit the backend again")
+	}
+}
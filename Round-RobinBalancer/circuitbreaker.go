@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+const (
+	breakerWindowSize   = 20
+	breakerFailureRatio = 0.5
+	breakerOpenDuration = 5 * time.Second
+)
+
+// circuitBreaker — простой circuit breaker на скользящем окне последних
+// результатов запросов к backend'у
+//
+// closed - запросы проходят как обычно, результаты копятся в окне
+// open - запросы не пропускаются до истечения breakerOpenDuration
+// half-open - пропускается один пробный запрос; успех закрывает breaker,
+//
+//	неудача снова его открывает
+type circuitBreaker struct {
+	mu    sync.Mutex
+	state breakerState
+
+	window []bool // true - успех, false - ошибка/таймаут
+
+	openedAt      time.Time
+	halfOpenInUse bool
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{state: breakerClosed}
+}
+
+// allow сообщает, можно ли сейчас отправить запрос через этот breaker
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < breakerOpenDuration {
+			return false
+		}
+
+		cb.state = breakerHalfOpen
+		cb.halfOpenInUse = false
+		fallthrough
+	case breakerHalfOpen:
+		if cb.halfOpenInUse {
+			return false
+		}
+
+		cb.halfOpenInUse = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordResult учитывает результат запроса, прошедшего через allow()
+func (cb *circuitBreaker) recordResult(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == breakerHalfOpen {
+		cb.halfOpenInUse = false
+		if success {
+			cb.state = breakerClosed
+			cb.window = nil
+		} else {
+			cb.state = breakerOpen
+			cb.openedAt = time.Now()
+		}
+		return
+	}
+
+	cb.window = append(cb.window, success)
+	if len(cb.window) > breakerWindowSize {
+		cb.window = cb.window[len(cb.window)-breakerWindowSize:]
+	}
+
+	if len(cb.window) < breakerWindowSize {
+		return
+	}
+
+	failures := 0
+	for _, ok := range cb.window {
+		if !ok {
+			failures++
+		}
+	}
+
+	if float64(failures)/float64(len(cb.window)) >= breakerFailureRatio {
+		cb.state = breakerOpen
+		cb.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// StartHealthChecks запускает фоновую проверку здоровья backend'ов
+//
+// Параметры:
+//
+//	ctx - контекст, отмена которого останавливает проверки
+//	path - путь, на который отправляется проверочный GET (например, "/healthz")
+//	interval - как часто опрашивать backend'ы
+//
+// Backend считается здоровым, если проверочный запрос завершился без
+// ошибки и вернул статус < 500. Проверки backend'ов выполняются
+// параллельно, чтобы один медленный backend не задерживал остальные.
+func (lb *LoadBalancer) StartHealthChecks(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				lb.checkBackends(path)
+			}
+		}
+	}()
+}
+
+func (lb *LoadBalancer) checkBackends(path string) {
+	for _, b := range lb.pool {
+		go lb.checkBackend(b, path)
+	}
+}
+
+func (lb *LoadBalancer) checkBackend(b *Backend, path string) {
+	resp, err := lb.Client.Get(b.URL + path)
+	if err != nil {
+		b.setHealthy(false)
+		lb.reportBackendUp(b.URL, false)
+		return
+	}
+	defer resp.Body.Close()
+
+	healthy := resp.StatusCode < 500
+	b.setHealthy(healthy)
+	lb.reportBackendUp(b.URL, healthy)
+}
+
+func (lb *LoadBalancer) reportBackendUp(backend string, healthy bool) {
+	if lb.metrics == nil {
+		return
+	}
+
+	value := 0.0
+	if healthy {
+		value = 1.0
+	}
+	lb.metrics.BalancerBackendUp.WithLabelValues(backend).Set(value)
+}
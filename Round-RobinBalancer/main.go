@@ -4,35 +4,77 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"sync"
-	"sync/atomic"
+	"strconv"
 	"time"
+
+	"github.com/StewardMcCormick/LMSEntryRepo/metrics"
 )
 
+const maxAttempts = 3
+
 // LoadBalancer распределяет запросы между backend'ами
 type LoadBalancer struct {
 	Backends []string
-	Counter  int64
+	Strategy BalancingStrategy
 	Stats    BalancerStats
 	Client   http.Client
+
+	pool    []*Backend
+	metrics *metrics.Collectors
+}
+
+// Option настраивает LoadBalancer при создании
+type Option func(*LoadBalancer)
+
+// WithMetrics включает экспорт метрик Prometheus через коллекторы c
+func WithMetrics(c *metrics.Collectors) Option {
+	return func(lb *LoadBalancer) {
+		lb.metrics = c
+	}
 }
 
 // NewLoadBalancer создаёт новый балансировщик
 // backends — список URL backend-серверов (например, []string{"http://server1:8080", "http://server2:8080"})
-func NewLoadBalancer(backends []string) *LoadBalancer {
+//
+// По умолчанию используется стратегия Round-Robin. Чтобы переключиться
+// на другую (LeastConnections/Weighted/P2C-EWMA), используйте
+// lb.SetStrategy вместе с lb.BackendPool().
+func NewLoadBalancer(backends []string, opts ...Option) *LoadBalancer {
+	pool := make([]*Backend, len(backends))
+	for i, url := range backends {
+		pool[i] = newBackend(url, 1)
+	}
+
 	lb := &LoadBalancer{
 		Backends: backends,
-		Stats:    BalancerStats{RequestsPerBackend: make(map[string]int)},
+		Stats:    newBalancerStats(),
 		Client:   http.Client{Timeout: time.Second * 3},
+		pool:     pool,
+	}
+	lb.Strategy = NewRoundRobinStrategy(pool)
+
+	for _, opt := range opts {
+		opt(lb)
 	}
 
 	return lb
 }
 
+// BackendPool возвращает внутреннее представление backend'ов, которое
+// нужно стратегиям балансировки (например, для NewWeightedStrategy)
+func (lb *LoadBalancer) BackendPool() []*Backend {
+	return lb.pool
+}
+
+// SetStrategy переключает алгоритм выбора backend'а
+func (lb *LoadBalancer) SetStrategy(strategy BalancingStrategy) {
+	lb.Strategy = strategy
+}
+
 // Get выполняет HTTP GET запрос к указанному пути
-// Запросы распределяются по Round-Robin алгоритму
 //
 // Параметры:
 //
@@ -44,56 +86,18 @@ func NewLoadBalancer(backends []string) *LoadBalancer {
 //	[]byte - полное тело ответа (даже если оно было chunked)
 //	error - ошибка, если все попытки исчерпаны
 //
-// Алгоритм работы:
-// 1. Выбрать следующий backend по Round-Robin
-// 2. Сделать GET запрос с таймаутом 3 секунды
-// 3. Если получен статус 2xx:
-//   - Прочитать всё тело ответа (даже если chunked)
-//   - Вернуть данные
-//
-// 4. Если получен статус 5xx или ошибка:
-//   - Попробовать следующий backend (до 3 попыток)
-//
-// 5. Если все попытки исчерпаны — вернуть ошибку
+// Backend для каждой попытки выбирается через lb.Strategy, которая
+// пропускает backend'ы, помеченные нездоровыми или с открытым circuit
+// breaker'ом. Если получен статус 5xx или произошла ошибка, запрос
+// повторяется на другом backend'е (до maxAttempts попыток).
 func (lb *LoadBalancer) Get(ctx context.Context, path string) ([]byte, error) {
-	backendNum := lb.Counter % int64(len(lb.Backends))
-	url := lb.Backends[backendNum] + path
-	var tryCount int
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
-		for tryCount <= 2 {
-			lb.Stats.AddRequest(lb.Backends[backendNum])
-			atomic.AddInt64(&lb.Counter, 1)
-
-			resp, err := lb.Client.Get(url)
-
-			if err != nil {
-				tryCount++
-				resp.Body.Close()
-				continue
-			}
-
-			if resp.Status[0] == '5' {
-				tryCount++
-				resp.Body.Close()
-				continue
-			}
-
-			respBody, err := io.ReadAll(resp.Body)
-			resp.Body.Close()
-
-			if err != nil {
-				return nil, err
-			}
-
-			return respBody, nil
-		}
+	resp, _, err := lb.RoundTrip(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	return nil, errors.New("")
+	return io.ReadAll(resp.Body)
 }
 
 // Post выполняет HTTP POST запрос
@@ -110,63 +114,105 @@ func (lb *LoadBalancer) Get(ctx context.Context, path string) ([]byte, error) {
 //	[]byte - тело ответа
 //	error - ошибка
 func (lb *LoadBalancer) Post(ctx context.Context, path string, body []byte) ([]byte, error) {
-	backendNum := lb.Counter % int64(len(lb.Backends))
-	url := lb.Backends[backendNum] + path
-	var tryCount int
+	resp, _, err := lb.RoundTrip(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
 
+// RoundTrip выполняет запрос к одному из backend'ов, выбранному через
+// lb.Strategy, и возвращает необработанный *http.Response вместе с URL
+// backend'а, который его обслужил. В отличие от Get/Post тело ответа не
+// читается и не закрывается — это остаётся на вызывающей стороне.
+//
+// header, если не nil, добавляется к запросу (используется, например,
+// CachingLoadBalancer для условной валидации через If-None-Match).
+// При статусе 5xx или сетевой ошибке запрос повторяется на другом
+// backend'е (до maxAttempts попыток).
+func (lb *LoadBalancer) RoundTrip(ctx context.Context, method, path string, body []byte, header http.Header) (*http.Response, string, error) {
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, "", ctx.Err()
 	default:
-		for tryCount <= 2 {
-			lb.Stats.AddRequest(lb.Backends[backendNum])
-			atomic.AddInt64(&lb.Counter, 1)
-
-			resp, err := lb.Client.Post(url, "text/json", bytes.NewBuffer(body))
-			if err != nil {
-				tryCount++
-				resp.Body.Close()
-				continue
+	}
+
+	probe, err := http.NewRequestWithContext(ctx, method, path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt <= maxAttempts; attempt++ {
+		backendURL, release := lb.Strategy.Pick(probe)
+		if backendURL == "" {
+			if lastErr != nil {
+				return nil, "", lastErr
 			}
+			return nil, "", errors.New("load balancer: no healthy backends available")
+		}
 
-			if resp.Status[0] == '5' {
-				tryCount++
-				resp.Body.Close()
-				continue
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, backendURL+path, bodyReader)
+		if err != nil {
+			return nil, "", err
+		}
+		if method == http.MethodPost {
+			req.Header.Set("Content-Type", "text/json")
+		}
+		for k, values := range header {
+			for _, v := range values {
+				req.Header.Add(k, v)
 			}
+		}
 
-			respBody, err := io.ReadAll(resp.Body)
-			resp.Body.Close()
+		start := time.Now()
+		resp, err := lb.Client.Do(req)
+		latency := time.Since(start)
 
-			if err != nil {
-				return nil, err
+		if err != nil {
+			lb.Stats.record(backendURL, 0, latency)
+			lb.reportRequest(backendURL, 0, latency)
+			if release != nil {
+				release(0, latency)
 			}
+			lastErr = err
+			continue
+		}
 
-			return respBody, nil
+		status := resp.StatusCode
+		lb.Stats.record(backendURL, status, latency)
+		lb.reportRequest(backendURL, status, latency)
+		if release != nil {
+			release(status, latency)
 		}
-	}
 
-	return nil, errors.New("")
-}
+		if status >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("load balancer: backend %s returned status %d", backendURL, status)
+			continue
+		}
 
-// GetStats возвращает статистику запросов
-func (lb *LoadBalancer) GetStats() BalancerStats {
-	return lb.Stats
-}
+		return resp, backendURL, nil
+	}
 
-// BalancerStats Stats содержит статистику балансировщика
-type BalancerStats struct {
-	TotalRequests      int            // Общее количество запросов
-	RequestsPerBackend map[string]int // Количество запросов на каждый backend
+	return nil, "", lastErr
 }
 
-func (bs *BalancerStats) AddRequest(backend string) {
-	mu := &sync.Mutex{}
-	mu.Lock()
-	defer mu.Unlock()
+func (lb *LoadBalancer) reportRequest(backend string, status int, latency time.Duration) {
+	if lb.metrics == nil {
+		return
+	}
 
-	bs.TotalRequests++
-	bs.RequestsPerBackend[backend]++
+	lb.metrics.BalancerRequestsTotal.WithLabelValues(backend, strconv.Itoa(status)).Inc()
+	lb.metrics.BalancerRequestDuration.WithLabelValues(backend).Observe(latency.Seconds())
 }
 
 func main() {
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// BackendCounters содержит счётчики запросов и гистограмму задержек
+// одного backend'а
+type BackendCounters struct {
+	Requests2xx int            // Количество ответов 2xx
+	Requests5xx int            // Количество ответов 5xx
+	Errors      int            // Количество сетевых ошибок/таймаутов
+	Latency     map[string]int // Гистограмма задержек: бакет -> количество
+}
+
+// BalancerStats содержит статистику работы балансировщика
+//
+// Все поля защищены mu — TotalRequests/RequestsPerBackend/PerBackend
+// пишутся из горутин, обслуживающих параллельные запросы RoundTrip, и
+// читаются через GetStats в произвольный момент.
+type BalancerStats struct {
+	TotalRequests      int                         // Общее количество запросов
+	RequestsPerBackend map[string]int              // Количество запросов на каждый backend
+	PerBackend         map[string]*BackendCounters // Детальные счётчики по backend'ам
+
+	mu *sync.Mutex
+}
+
+func newBalancerStats() BalancerStats {
+	return BalancerStats{
+		RequestsPerBackend: make(map[string]int),
+		PerBackend:         make(map[string]*BackendCounters),
+		mu:                 &sync.Mutex{},
+	}
+}
+
+// AddRequest регистрирует попытку запроса к backend'у
+func (bs *BalancerStats) AddRequest(backend string) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	bs.TotalRequests++
+	bs.RequestsPerBackend[backend]++
+}
+
+// record обновляет детальные счётчики backend'а статусом ответа и
+// задержкой запроса. status == 0 означает сетевую ошибку или таймаут.
+func (bs *BalancerStats) record(backend string, status int, latency time.Duration) {
+	bs.AddRequest(backend)
+
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	counters, ok := bs.PerBackend[backend]
+	if !ok {
+		counters = &BackendCounters{Latency: make(map[string]int)}
+		bs.PerBackend[backend] = counters
+	}
+
+	switch {
+	case status == 0:
+		counters.Errors++
+	case status >= 500:
+		counters.Requests5xx++
+	case status >= 200 && status < 300:
+		counters.Requests2xx++
+	}
+
+	counters.Latency[latencyBucket(latency)]++
+}
+
+func latencyBucket(d time.Duration) string {
+	switch {
+	case d < 10*time.Millisecond:
+		return "<10ms"
+	case d < 50*time.Millisecond:
+		return "<50ms"
+	case d < 100*time.Millisecond:
+		return "<100ms"
+	case d < 500*time.Millisecond:
+		return "<500ms"
+	case d < time.Second:
+		return "<1s"
+	default:
+		return ">=1s"
+	}
+}
+
+// GetStats возвращает снимок статистики запросов
+//
+// Возвращённое значение — независимая копия: дальнейшие запросы,
+// изменяющие lb.Stats, на неё не повлияют.
+func (lb *LoadBalancer) GetStats() BalancerStats {
+	lb.Stats.mu.Lock()
+	defer lb.Stats.mu.Unlock()
+
+	snapshot := BalancerStats{
+		TotalRequests:      lb.Stats.TotalRequests,
+		RequestsPerBackend: make(map[string]int, len(lb.Stats.RequestsPerBackend)),
+		PerBackend:         make(map[string]*BackendCounters, len(lb.Stats.PerBackend)),
+	}
+
+	for backend, count := range lb.Stats.RequestsPerBackend {
+		snapshot.RequestsPerBackend[backend] = count
+	}
+
+	for backend, counters := range lb.Stats.PerBackend {
+		latency := make(map[string]int, len(counters.Latency))
+		for bucket, count := range counters.Latency {
+			latency[bucket] = count
+		}
+		snapshot.PerBackend[backend] = &BackendCounters{
+			Requests2xx: counters.Requests2xx,
+			Requests5xx: counters.Requests5xx,
+			Errors:      counters.Errors,
+			Latency:     latency,
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancingStrategy выбирает backend для очередного запроса
+//
+// Pick возвращает URL выбранного backend'а и release — функцию,
+// которую вызывающий должен вызвать после завершения запроса, передав
+// итоговый HTTP-статус (0, если запрос завершился ошибкой/таймаутом) и
+// задержку. Если здоровых backend'ов нет, Pick возвращает пустую строку
+// и nil release.
+type BalancingStrategy interface {
+	Pick(req *http.Request) (backend string, release func(status int, latency time.Duration))
+}
+
+func availableBackends(backends []*Backend) []*Backend {
+	out := make([]*Backend, 0, len(backends))
+	for _, b := range backends {
+		if b.isAvailable() {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func releaseFor(b *Backend) func(status int, latency time.Duration) {
+	return func(status int, latency time.Duration) {
+		success := status >= 200 && status < 500
+		b.breaker.recordResult(success)
+		b.recordLatency(float64(latency.Milliseconds()))
+	}
+}
+
+// RoundRobinStrategy выбирает backend'ы по очереди, пропуская недоступные
+type RoundRobinStrategy struct {
+	backends []*Backend
+	counter  int64
+}
+
+func NewRoundRobinStrategy(backends []*Backend) *RoundRobinStrategy {
+	return &RoundRobinStrategy{backends: backends}
+}
+
+func (s *RoundRobinStrategy) Pick(req *http.Request) (string, func(int, time.Duration)) {
+	n := len(s.backends)
+	if n == 0 {
+		return "", nil
+	}
+
+	for i := 0; i < n; i++ {
+		idx := int(uint64(atomic.AddInt64(&s.counter, 1)-1) % uint64(n))
+		b := s.backends[idx]
+		if b.isAvailable() {
+			return b.URL, releaseFor(b)
+		}
+	}
+
+	return "", nil
+}
+
+// LeastConnectionsStrategy выбирает backend с наименьшим числом активных
+// в данный момент соединений
+type LeastConnectionsStrategy struct {
+	backends []*Backend
+}
+
+func NewLeastConnectionsStrategy(backends []*Backend) *LeastConnectionsStrategy {
+	return &LeastConnectionsStrategy{backends: backends}
+}
+
+func (s *LeastConnectionsStrategy) Pick(req *http.Request) (string, func(int, time.Duration)) {
+	var chosen *Backend
+	var min int64 = math.MaxInt64
+
+	for _, b := range s.backends {
+		if !b.isAvailable() {
+			continue
+		}
+
+		if c := b.conns(); c < min {
+			min = c
+			chosen = b
+		}
+	}
+
+	if chosen == nil {
+		return "", nil
+	}
+
+	chosen.incConns()
+	release := releaseFor(chosen)
+	return chosen.URL, func(status int, latency time.Duration) {
+		chosen.decConns()
+		release(status, latency)
+	}
+}
+
+// WeightedStrategy распределяет запросы пропорционально весам backend'ов
+// по алгоритму smooth weighted round-robin (как в nginx)
+type WeightedStrategy struct {
+	mu       sync.Mutex
+	backends []*Backend
+}
+
+func NewWeightedStrategy(backends []*Backend) *WeightedStrategy {
+	return &WeightedStrategy{backends: backends}
+}
+
+func (s *WeightedStrategy) Pick(req *http.Request) (string, func(int, time.Duration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	available := availableBackends(s.backends)
+	if len(available) == 0 {
+		return "", nil
+	}
+
+	totalWeight := 0
+	var chosen *Backend
+
+	for _, b := range available {
+		b.currentWeight += int64(b.Weight)
+		totalWeight += b.Weight
+
+		if chosen == nil || b.currentWeight > chosen.currentWeight {
+			chosen = b
+		}
+	}
+
+	chosen.currentWeight -= int64(totalWeight)
+	return chosen.URL, releaseFor(chosen)
+}
+
+// P2CEWMAStrategy реализует "power of two choices": случайно берутся два
+// доступных backend'а и выбирается тот, у которого меньше экспоненциально
+// сглаженная задержка (EWMA). Это хорошо балансирует нагрузку без
+// необходимости держать точное число активных соединений на каждом узле.
+type P2CEWMAStrategy struct {
+	backends []*Backend
+	rnd      *rand.Rand
+	mu       sync.Mutex
+}
+
+func NewP2CEWMAStrategy(backends []*Backend) *P2CEWMAStrategy {
+	return &P2CEWMAStrategy{
+		backends: backends,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (s *P2CEWMAStrategy) Pick(req *http.Request) (string, func(int, time.Duration)) {
+	available := availableBackends(s.backends)
+	if len(available) == 0 {
+		return "", nil
+	}
+
+	if len(available) == 1 {
+		return available[0].URL, releaseFor(available[0])
+	}
+
+	s.mu.Lock()
+	i, j := s.rnd.Intn(len(available)), s.rnd.Intn(len(available)-1)
+	s.mu.Unlock()
+
+	if j >= i {
+		j++
+	}
+
+	first, second := available[i], available[j]
+	chosen := first
+	if second.latencyEstimate() < first.latencyEstimate() {
+		chosen = second
+	}
+
+	return chosen.URL, releaseFor(chosen)
+}
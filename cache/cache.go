@@ -0,0 +1,320 @@
+// Package cache реализует TTL/LRU-кеш в оперативной памяти
+// (CacheManager), его постоянный bbolt-тир (PersistentCacheManager) и
+// HTTP-расширения (SetHTTP/GetHTTP/GetHTTPEntry), используемые
+// CachingLoadBalancer в Round-RobinBalancer.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/StewardMcCormick/LMSEntryRepo/metrics"
+)
+
+// CacheValue хранит значение вместе со служебными полями кеша
+//
+// Value должен быть либо []byte, либо типом, зарегистрированным через
+// RegisterType — это нужно только для PersistentCacheManager, который
+// кодирует Value через encoding/gob при записи на диск
+type CacheValue struct {
+	Value      interface{}
+	TTL        time.Duration
+	LastAccess time.Time
+	Header     http.Header // HTTP-заголовки, сохранённые вместе со значением (для кеширования HTTP-ответов)
+	Status     int         // HTTP-статус ответа, если значение получено из HTTP-кеша
+
+	elem *list.Element // позиция ключа в списке порядка использования (LRU)
+}
+
+// CacheManager управляет кешем с TTL
+type CacheManager struct {
+	Cache       map[string]*CacheValue
+	Capacity    int
+	DefaultTTL  time.Duration
+	CleanupTick time.Duration
+	Stats       CacheStats
+	mu          *sync.RWMutex
+
+	order   *list.List // ключи от самого недавно используемого (Front) до самого старого (Back)
+	metrics *metrics.Collectors
+}
+
+// Option настраивает CacheManager при создании
+type Option func(*CacheManager)
+
+// WithMetrics включает экспорт метрик Prometheus через коллекторы c
+func WithMetrics(c *metrics.Collectors) Option {
+	return func(cm *CacheManager) {
+		cm.metrics = c
+	}
+}
+
+// NewCacheManager создаёт новый кеш-менеджер
+//
+// Параметры:
+//
+//	maxSize - максимальное количество записей в кеше
+//	defaultTTL - время жизни записи по умолчанию
+func NewCacheManager(maxSize int, defaultTTL, cleanupTick time.Duration, opts ...Option) *CacheManager {
+	cm := &CacheManager{
+		Cache:       make(map[string]*CacheValue),
+		Capacity:    maxSize,
+		DefaultTTL:  defaultTTL,
+		CleanupTick: cleanupTick,
+		Stats:       CacheStats{},
+		mu:          &sync.RWMutex{},
+		order:       list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(cm)
+	}
+
+	return cm
+}
+
+// touch отмечает key как самый недавно использованный — O(1) за счёт
+// хранения *list.Element прямо в CacheValue
+func (cm *CacheManager) touch(v *CacheValue, key string) {
+	if v.elem == nil {
+		v.elem = cm.order.PushFront(key)
+		return
+	}
+
+	cm.order.MoveToFront(v.elem)
+}
+
+// lru вытесняет самую давно не использовавшуюся запись за O(1)
+func (cm *CacheManager) lru() {
+	back := cm.order.Back()
+	if back == nil {
+		return
+	}
+
+	key := back.Value.(string)
+	cm.order.Remove(back)
+	delete(cm.Cache, key)
+	atomic.AddInt64(&cm.Stats.Evictions, 1)
+
+	if cm.metrics != nil {
+		cm.metrics.CacheEvictions.Inc()
+	}
+}
+
+func (cm *CacheManager) set(key string, v *CacheValue) {
+	_, ok := cm.Cache[key]
+	if !ok && len(cm.Cache) == cm.Capacity {
+		cm.lru()
+	}
+
+	cm.Cache[key] = v
+	cm.touch(v, key)
+}
+
+// Set добавляет или обновляет запись в кеше
+// Если кеш переполнен, удаляется самая давно не использовавшаяся запись (LRU)
+func (cm *CacheManager) Set(key string, value interface{}) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.set(key, &CacheValue{
+		Value:      value,
+		TTL:        cm.DefaultTTL,
+		LastAccess: time.Now(),
+	})
+}
+
+// SetWithTTL добавляет запись с кастомным TTL
+func (cm *CacheManager) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.set(key, &CacheValue{
+		Value:      value,
+		TTL:        ttl,
+		LastAccess: time.Now(),
+	})
+}
+
+// Get получает значение из кеша
+// При успешном чтении обновляет время последнего доступа (продлевает TTL)
+// Возвращает (value, true) если ключ найден и не устарел
+// Возвращает (nil, false) если ключ не найден или устарел
+func (cm *CacheManager) Get(key string) (interface{}, bool) {
+	cm.mu.Lock()
+	v, ok := cm.Cache[key]
+	if ok {
+		cm.touch(v, key)
+	}
+	cm.mu.Unlock()
+
+	if !ok || time.Since(v.LastAccess) > v.TTL {
+		atomic.AddInt64(&cm.Stats.Misses, 1)
+		if cm.metrics != nil {
+			cm.metrics.CacheMisses.Inc()
+		}
+		return nil, false
+	}
+
+	atomic.AddInt64(&cm.Stats.Hits, 1)
+	if cm.metrics != nil {
+		cm.metrics.CacheHits.Inc()
+	}
+	return v.Value, true
+}
+
+// SetHTTP сохраняет тело HTTP-ответа вместе со статусом и заголовками
+func (cm *CacheManager) SetHTTP(key string, status int, header http.Header, body []byte, ttl time.Duration) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.set(key, &CacheValue{
+		Value:      body,
+		TTL:        ttl,
+		LastAccess: time.Now(),
+		Header:     header,
+		Status:     status,
+	})
+}
+
+// GetHTTP читает закешированный HTTP-ответ
+// Возвращает ok=false, если ключ не найден, устарел, либо значение было
+// сохранено не через SetHTTP
+func (cm *CacheManager) GetHTTP(key string) (status int, header http.Header, body []byte, ok bool) {
+	cm.mu.Lock()
+	v, found := cm.Cache[key]
+	if found {
+		cm.touch(v, key)
+	}
+	cm.mu.Unlock()
+
+	if !found || time.Since(v.LastAccess) > v.TTL {
+		atomic.AddInt64(&cm.Stats.Misses, 1)
+		return 0, nil, nil, false
+	}
+
+	body, isBytes := v.Value.([]byte)
+	if !isBytes {
+		atomic.AddInt64(&cm.Stats.Misses, 1)
+		return 0, nil, nil, false
+	}
+
+	atomic.AddInt64(&cm.Stats.Hits, 1)
+	return v.Status, v.Header, body, true
+}
+
+// GetHTTPEntry возвращает закешированный HTTP-ответ вместе с пометкой
+// о том, не устарел ли он (stale), не удаляя запись из кеша и не
+// учитывая обращение в Stats.Hits/Misses. Используется для
+// stale-while-revalidate, когда устаревшую запись ещё можно отдать.
+func (cm *CacheManager) GetHTTPEntry(key string) (status int, header http.Header, body []byte, stale bool, ok bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	v, found := cm.Cache[key]
+	if !found {
+		return 0, nil, nil, false, false
+	}
+	cm.touch(v, key)
+
+	body, isBytes := v.Value.([]byte)
+	if !isBytes {
+		return 0, nil, nil, false, false
+	}
+
+	return v.Status, v.Header, body, time.Since(v.LastAccess) > v.TTL, true
+}
+
+// Delete удаляет запись из кеша
+func (cm *CacheManager) Delete(key string) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.deleteLocked(key)
+}
+
+// deleteLocked удаляет запись из кеша; вызывающий должен удерживать cm.mu
+func (cm *CacheManager) deleteLocked(key string) {
+	if v, ok := cm.Cache[key]; ok {
+		delete(cm.Cache, key)
+		if v.elem != nil {
+			cm.order.Remove(v.elem)
+		}
+	}
+}
+
+// Clear очищает весь кеш
+func (cm *CacheManager) Clear() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.Cache = make(map[string]*CacheValue)
+	cm.order.Init()
+}
+
+func (cm *CacheManager) ClearByTTL() {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	now := time.Now()
+	for k, v := range cm.Cache {
+		if now.Sub(v.LastAccess) > v.TTL {
+			atomic.AddInt64(&cm.Stats.Evictions, 1)
+			cm.deleteLocked(k)
+		}
+	}
+}
+
+// StartCleanup запускает фоновую очистку устаревших записей
+// Очистка выполняется каждые cleanupInterval
+// Останавливается при отмене контекста
+func (cm *CacheManager) StartCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cm.CleanupTick)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cm.ClearByTTL()
+			}
+		}
+	}()
+}
+
+// GetStats возвращает статистику кеша
+//
+// Hits/Misses/Evictions/Size читаются через atomic, так как пишутся
+// через atomic.AddInt64 из произвольных горутин — обычное копирование
+// структуры CacheStats было бы гонкой по данным.
+func (cm *CacheManager) GetStats() CacheStats {
+	cm.mu.RLock()
+	size := int64(len(cm.Cache))
+	cm.mu.RUnlock()
+
+	atomic.StoreInt64(&cm.Stats.Size, size)
+
+	if cm.metrics != nil {
+		cm.metrics.CacheSize.Set(float64(size))
+	}
+
+	return CacheStats{
+		Hits:      atomic.LoadInt64(&cm.Stats.Hits),
+		Misses:    atomic.LoadInt64(&cm.Stats.Misses),
+		Evictions: atomic.LoadInt64(&cm.Stats.Evictions),
+		Size:      size,
+	}
+}
+
+// CacheStats содержит статистику работы кеша
+type CacheStats struct {
+	Hits      int64 // Количество успешных Get
+	Misses    int64 // Количество неуспешных Get
+	Evictions int64 // Количество вытесненных записей (из-за переполнения или TTL)
+	Size      int64 // Текущий размер кеша
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheManager_EvictsLeastRecentlyUsed(t *testing.T) {
+	cm := NewCacheManager(2, time.Minute, time.Minute)
+
+	cm.Set("a", 1)
+	cm.Set("b", 2)
+
+	// обращение к "a" делает его более недавним, чем "b"
+	if _, ok := cm.Get("a"); !ok {
+		t.Fatal("expected a to be present")
+	}
+
+	cm.Set("c", 3)
+
+	if _, ok := cm.Get("b"); ok {
+		t.Fatal("expected b to be evicted as least recently used")
+	}
+
+	if _, ok := cm.Get("a"); !ok {
+		t.Fatal("expected a to survive eviction")
+	}
+
+	if _, ok := cm.Get("c"); !ok {
+		t.Fatal("expected c to be present")
+	}
+}
+
+func TestCacheManager_GetHTTPRoundTrip(t *testing.T) {
+	cm := NewCacheManager(10, time.Minute, time.Minute)
+
+	header := map[string][]string{"Content-Type": {"text/plain"}}
+	cm.SetHTTP("resp", 200, header, []byte("hello"), time.Minute)
+
+	status, gotHeader, body, ok := cm.GetHTTP("resp")
+	if !ok {
+		t.Fatal("expected cached HTTP response to be found")
+	}
+	if status != 200 || string(body) != "hello" || gotHeader.Get("Content-Type") != "text/plain" {
+		t.Fatalf("unexpected cached response: status=%d body=%s header=%v", status, body, gotHeader)
+	}
+}
+
+// Регрессионный тест: GetHTTP/GetHTTPEntry раньше не вызывали touch(),
+// поэтому чтение через них не продлевало запись в LRU — она вытеснялась
+// так же легко, как если бы её никто не читал.
+func TestCacheManager_GetHTTPEntryKeepsHotEntryInLRU(t *testing.T) {
+	cm := NewCacheManager(2, time.Minute, time.Minute)
+
+	cm.SetHTTP("hot", 200, nil, []byte("a"), time.Minute)
+	cm.SetHTTP("cold", 200, nil, []byte("b"), time.Minute)
+
+	for i := 0; i < 5; i++ {
+		if _, _, _, _, ok := cm.GetHTTPEntry("hot"); !ok {
+			t.Fatal("expected \"hot\" to be found")
+		}
+	}
+
+	cm.SetHTTP("new", 200, nil, []byte("c"), time.Minute) // должно вытеснить "cold", не "hot"
+
+	if _, _, _, _, ok := cm.GetHTTPEntry("hot"); !ok {
+		t.Fatal("expected frequently-read \"hot\" entry to survive eviction")
+	}
+	if _, _, _, _, ok := cm.GetHTTPEntry("cold"); ok {
+		t.Fatal("expected never-reread \"cold\" entry to be evicted instead")
+	}
+}
+
+// Регрессионный тест: Delete и GetStats раньше читали/писали cm.Cache
+// без удержания cm.mu, что конкурентно с Set/Get было гонкой по данным
+// под -race.
+func TestCacheManager_DeleteAndGetStatsAreRaceFree(t *testing.T) {
+	cm := NewCacheManager(1000, time.Minute, time.Minute)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := strconv.Itoa(i)
+			cm.Set(key, i)
+			cm.GetStats()
+			cm.Delete(key)
+		}(i)
+	}
+	wg.Wait()
+}
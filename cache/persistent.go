@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const persistentBucketName = "cache"
+
+// RegisterType регистрирует конкретный тип, который будет сохраняться в
+// PersistentCacheManager, для кодирования через encoding/gob
+//
+// Вызывается обычно из init() для каждого типа, кроме []byte, который
+// поддерживается без регистрации. Без регистрации Set/SetWithTTL
+// сохранят запись только в RAM-кеше, а запись на диск будет пропущена.
+func RegisterType(value interface{}) {
+	gob.Register(value)
+}
+
+// diskEntry — то, что реально лежит в bbolt: gob-кодированные Value,
+// TTL, LastAccess и ExpiresAt (последнее хранится отдельно, чтобы не
+// пересчитывать TTL-истечение каждый раз при сканировании бакета)
+type diskEntry struct {
+	Value      interface{}
+	TTL        time.Duration
+	LastAccess time.Time
+	ExpiresAt  time.Time
+}
+
+// PersistentCacheManager расширяет CacheManager необязательным диск-тиром
+// на bbolt, чтобы записи переживали перезапуск и кеш мог превышать объём
+// оперативной памяти
+//
+// Get сначала проверяет RAM, затем bbolt (при попадании на диске значение
+// поднимается обратно в RAM). Set/SetWithTTL/Delete пишут в оба тира
+// (write-through).
+type PersistentCacheManager struct {
+	*CacheManager
+
+	db *bbolt.DB
+}
+
+// NewPersistentCacheManager открывает (создавая при необходимости)
+// bbolt-базу по path и оборачивает её вокруг обычного CacheManager
+//
+// Параметры:
+//
+//	path - путь к файлу bbolt-базы
+//	maxSize - вместимость RAM-тира (как у NewCacheManager)
+//	defaultTTL - TTL по умолчанию
+//	cleanup - период фоновой очистки RAM-тира по TTL
+func NewPersistentCacheManager(path string, maxSize int, defaultTTL, cleanup time.Duration) (*PersistentCacheManager, error) {
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("persistent cache: open %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(persistentBucketName))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("persistent cache: create bucket: %w", err)
+	}
+
+	return &PersistentCacheManager{
+		CacheManager: NewCacheManager(maxSize, defaultTTL, cleanup),
+		db:           db,
+	}, nil
+}
+
+func encodeDiskEntry(e diskEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeDiskEntry(raw []byte) (diskEntry, error) {
+	var e diskEntry
+	err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&e)
+	return e, err
+}
+
+func (pcm *PersistentCacheManager) writeThrough(key string, value interface{}, ttl time.Duration) {
+	entry := diskEntry{
+		Value:      value,
+		TTL:        ttl,
+		LastAccess: time.Now(),
+		ExpiresAt:  time.Now().Add(ttl),
+	}
+
+	raw, err := encodeDiskEntry(entry)
+	if err != nil {
+		// значение не зарегистрировано через RegisterType (и не []byte) —
+		// остаётся только в RAM-тире
+		return
+	}
+
+	_ = pcm.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(persistentBucketName)).Put([]byte(key), raw)
+	})
+}
+
+// Set добавляет или обновляет запись в RAM и на диске
+func (pcm *PersistentCacheManager) Set(key string, value interface{}) {
+	pcm.CacheManager.Set(key, value)
+	pcm.writeThrough(key, value, pcm.DefaultTTL)
+}
+
+// SetWithTTL добавляет запись с кастомным TTL в RAM и на диске
+func (pcm *PersistentCacheManager) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	pcm.CacheManager.SetWithTTL(key, value, ttl)
+	pcm.writeThrough(key, value, ttl)
+}
+
+// Get читает значение сначала из RAM, а при промахе — из bbolt,
+// поднимая найденную запись обратно в RAM
+func (pcm *PersistentCacheManager) Get(key string) (interface{}, bool) {
+	if v, ok := pcm.CacheManager.Get(key); ok {
+		return v, true
+	}
+
+	var entry diskEntry
+	var found bool
+
+	_ = pcm.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket([]byte(persistentBucketName)).Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+
+		decoded, err := decodeDiskEntry(raw)
+		if err != nil {
+			return nil
+		}
+
+		entry = decoded
+		found = true
+		return nil
+	})
+
+	if !found || time.Now().After(entry.ExpiresAt) {
+		return nil, false
+	}
+
+	// продлеваем RAM-тир только до реального момента истечения записи,
+	// а не заново на полный entry.TTL — иначе каждый цикл
+	// вытеснение-из-RAM/чтение-с-диска отодвигал бы ExpiresAt и запись
+	// могла бы жить бесконечно дольше своего настоящего TTL
+	pcm.CacheManager.SetWithTTL(key, entry.Value, time.Until(entry.ExpiresAt))
+	return entry.Value, true
+}
+
+// Delete удаляет запись из RAM и с диска
+func (pcm *PersistentCacheManager) Delete(key string) {
+	pcm.CacheManager.Delete(key)
+	_ = pcm.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(persistentBucketName)).Delete([]byte(key))
+	})
+}
+
+// Compact проходит по bbolt-бакету и удаляет устаревшие записи,
+// возвращая их количество
+func (pcm *PersistentCacheManager) Compact() (int, error) {
+	removed := 0
+
+	err := pcm.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(persistentBucketName))
+		now := time.Now()
+
+		var expiredKeys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			entry, err := decodeDiskEntry(v)
+			if err != nil {
+				return nil
+			}
+
+			if now.After(entry.ExpiresAt) {
+				expiredKeys = append(expiredKeys, append([]byte(nil), k...))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expiredKeys {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			removed++
+		}
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// Close закрывает bbolt-базу
+func (pcm *PersistentCacheManager) Close() error {
+	return pcm.db.Close()
+}
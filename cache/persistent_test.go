@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestPersistentCacheManager(t *testing.T, maxSize int, defaultTTL time.Duration) *PersistentCacheManager {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cache.db")
+	pcm, err := NewPersistentCacheManager(path, maxSize, defaultTTL, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to open persistent cache: %v", err)
+	}
+	t.Cleanup(func() { pcm.Close() })
+
+	return pcm
+}
+
+// Значение, закешированное через Set, должно пережить вытеснение из RAM
+// (LRU) и быть поднято обратно из bbolt при следующем Get.
+func TestPersistentCacheManager_DiskRoundTripAfterRAMEviction(t *testing.T) {
+	RegisterType("")
+
+	pcm := newTestPersistentCacheManager(t, 1, time.Minute)
+
+	pcm.Set("a", "value-a")
+	pcm.Set("b", "value-b") // вытесняет "a" из RAM (capacity=1), но не с диска
+
+	if _, ok := pcm.CacheManager.Get("a"); ok {
+		t.Fatal("expected \"a\" to have been evicted from RAM")
+	}
+
+	v, ok := pcm.Get("a")
+	if !ok {
+		t.Fatal("expected \"a\" to be found on disk after RAM eviction")
+	}
+	if v != "value-a" {
+		t.Fatalf("unexpected value promoted from disk: %v", v)
+	}
+}
+
+// Регрессионный тест: Get раньше поднимал запись из bbolt обратно в
+// RAM с полным entry.TTL вместо оставшегося времени до ExpiresAt,
+// из-за чего запись могла жить куда дольше своего настоящего TTL.
+func TestPersistentCacheManager_PromotionUsesRemainingTTL(t *testing.T) {
+	RegisterType("")
+
+	pcm := newTestPersistentCacheManager(t, 1, time.Minute)
+
+	pcm.SetWithTTL("a", "value-a", 100*time.Millisecond)
+	pcm.SetWithTTL("b", "value-b", time.Minute) // вытесняет "a" из RAM
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, ok := pcm.Get("a"); !ok {
+		t.Fatal("expected \"a\" to still be fresh when promoted at 60ms of a 100ms TTL")
+	}
+
+	time.Sleep(60 * time.Millisecond) // суммарно 120ms > 100ms TTL
+
+	if _, ok := pcm.CacheManager.Get("a"); ok {
+		t.Fatal("expected \"a\" to be expired 20ms after its original 100ms TTL, not refreshed by promotion")
+	}
+}
+
+// Compact должен удалять из bbolt записи, чей ExpiresAt уже прошёл.
+func TestPersistentCacheManager_CompactRemovesExpiredKeys(t *testing.T) {
+	RegisterType("")
+
+	pcm := newTestPersistentCacheManager(t, 10, time.Minute)
+
+	pcm.SetWithTTL("expired", "value", 10*time.Millisecond)
+	pcm.SetWithTTL("fresh", "value", time.Minute)
+
+	time.Sleep(30 * time.Millisecond)
+
+	removed, err := pcm.Compact()
+	if err != nil {
+		t.Fatalf("unexpected error from Compact: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected Compact to remove 1 expired key, removed %d", removed)
+	}
+
+	// после Compact "expired" не должен подниматься с диска даже если
+	// случайно ещё жив в RAM-тире
+	pcm.CacheManager.Delete("expired")
+	if _, ok := pcm.Get("expired"); ok {
+		t.Fatal("expected \"expired\" to be gone from disk after Compact")
+	}
+
+	if _, ok := pcm.Get("fresh"); !ok {
+		t.Fatal("expected \"fresh\" to survive Compact")
+	}
+}
+
+// Значение незарегистрированного через RegisterType типа не может быть
+// gob-закодировано, поэтому write-through на диск молча пропускается
+// и запись остаётся только в RAM-тире, как документирует RegisterType.
+func TestPersistentCacheManager_UnregisteredTypeStaysRAMOnly(t *testing.T) {
+	type unregistered struct{ N int }
+
+	pcm := newTestPersistentCacheManager(t, 1, time.Minute)
+
+	pcm.Set("a", unregistered{N: 1})
+	pcm.Set("b", unregistered{N: 2}) // вытесняет "a" из RAM
+
+	if _, ok := pcm.Get("a"); ok {
+		t.Fatal("expected unregistered-type value to be unrecoverable from disk after RAM eviction")
+	}
+}
@@ -0,0 +1,123 @@
+// Package metrics собирает Prometheus-коллекторы, используемые всеми
+// подсистемами этого репозитория (CacheManager, RateLimiter, LoadBalancer,
+// ProcessFiles). Подсистемы принимают *Collectors через опцию WithMetrics
+// и сами решают, какие поля обновлять — этот пакет только объявляет и
+// регистрирует метрики.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collectors — набор всех метрик, регистрируемых этим репозиторием
+type Collectors struct {
+	CacheHits      prometheus.Counter
+	CacheMisses    prometheus.Counter
+	CacheEvictions prometheus.Counter
+	CacheSize      prometheus.Gauge
+
+	RateLimiterTokensAvailable prometheus.Gauge
+	RateLimiterWaitDuration    prometheus.Histogram
+	RateLimiterRejections      prometheus.Counter
+
+	BalancerRequestsTotal   *prometheus.CounterVec
+	BalancerRequestDuration *prometheus.HistogramVec
+	BalancerBackendUp       *prometheus.GaugeVec
+
+	FilesTotal      prometheus.Counter
+	FileLines       prometheus.Counter
+	FileWords       prometheus.Counter
+	FileProcessTime prometheus.Histogram
+}
+
+// New создаёт коллекторы и регистрирует их в reg
+//
+// reg обычно — отдельный *prometheus.Registry на процесс (или
+// prometheus.DefaultRegisterer), переданный подсистемам через WithMetrics
+func New(reg prometheus.Registerer) *Collectors {
+	c := &Collectors{
+		CacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_hits_total",
+			Help: "Количество успешных обращений к кешу",
+		}),
+		CacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_misses_total",
+			Help: "Количество неуспешных обращений к кешу",
+		}),
+		CacheEvictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Количество вытесненных записей кеша",
+		}),
+		CacheSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cache_size",
+			Help: "Текущее количество записей в кеше",
+		}),
+
+		RateLimiterTokensAvailable: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "ratelimiter_tokens_available",
+			Help: "Количество доступных токенов в ведре",
+		}),
+		RateLimiterWaitDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ratelimiter_wait_duration_seconds",
+			Help:    "Время ожидания токена в Wait/WaitN",
+			Buckets: prometheus.DefBuckets,
+		}),
+		RateLimiterRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ratelimiter_rejections_total",
+			Help: "Количество отклонённых запросов Allow",
+		}),
+
+		BalancerRequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "balancer_requests_total",
+			Help: "Количество запросов балансировщика по backend'у и статусу",
+		}, []string{"backend", "status"}),
+		BalancerRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "balancer_request_duration_seconds",
+			Help:    "Длительность запросов к backend'ам",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"backend"}),
+		BalancerBackendUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "balancer_backend_up",
+			Help: "1, если backend считается здоровым, иначе 0",
+		}, []string{"backend"}),
+
+		FilesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "fileagg_files_total",
+			Help: "Количество обработанных файлов",
+		}),
+		FileLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "fileagg_lines_total",
+			Help: "Суммарное количество строк во всех обработанных файлах",
+		}),
+		FileWords: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "fileagg_words_total",
+			Help: "Суммарное количество слов во всех обработанных файлах",
+		}),
+		// Без лейбла по path: путей к файлам неограниченно много, а
+		// client_golang никогда не вытесняет комбинации лейблов —
+		// лейбл с путём файла рано или поздно исчерпает память процесса
+		FileProcessTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "fileagg_file_duration_seconds",
+			Help:    "Время обработки одного файла",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+
+	reg.MustRegister(
+		c.CacheHits, c.CacheMisses, c.CacheEvictions, c.CacheSize,
+		c.RateLimiterTokensAvailable, c.RateLimiterWaitDuration, c.RateLimiterRejections,
+		c.BalancerRequestsTotal, c.BalancerRequestDuration, c.BalancerBackendUp,
+		c.FilesTotal, c.FileLines, c.FileWords, c.FileProcessTime,
+	)
+
+	return c
+}
+
+// Handler возвращает http.Handler, отдающий метрики из reg в формате
+// exposition на /metrics
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
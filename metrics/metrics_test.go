@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNew_RegistersAllCollectorsExactlyOnce(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c := New(reg)
+
+	c.CacheHits.Inc()
+	c.BalancerRequestsTotal.WithLabelValues("http://backend-1", "200").Inc()
+
+	if got := testutil.ToFloat64(c.CacheHits); got != 1 {
+		t.Fatalf("expected CacheHits=1, got %v", got)
+	}
+
+	gathered, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+	if len(gathered) == 0 {
+		t.Fatal("expected at least one registered metric family")
+	}
+}